@@ -1,10 +1,12 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strconv"
 	"syscall"
+	"time"
 
 	"github.com/syossan27/tebata"
 )
@@ -14,7 +16,7 @@ import (
 //	3
 //	Hello
 func main() {
-	t := tebata.New(syscall.SIGINT, syscall.SIGTERM)
+	t := tebata.New(syscall.SIGINT, syscall.SIGTERM).SetTimeout(5 * time.Second)
 
 	// Do function when catch signal.
 	if err := t.Reserve(sum, 1, 2); err != nil {
@@ -25,13 +27,15 @@ func main() {
 		fmt.Printf("Failed to reserve hello function: %v\n", err)
 		return
 	}
-	if err := t.Reserve(os.Exit, 0); err != nil {
-		fmt.Printf("Failed to reserve exit function: %v\n", err)
-		return
-	}
 
 	fmt.Println("Signal handler registered. Press Ctrl+C to trigger.")
-	select {}
+
+	// Wait blocks until the signal's reserved functions have completed, or
+	// returns ErrShutdownTimeout if they take longer than SetTimeout allows.
+	if err := t.Wait(context.Background()); err != nil {
+		fmt.Printf("Shutdown did not complete cleanly: %v\n", err)
+		os.Exit(1)
+	}
 }
 
 func sum(firstArg, secondArg int) {
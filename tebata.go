@@ -4,10 +4,17 @@ package tebata
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"syscall"
+	"time"
 )
 
 // ErrInvalidFunction is returned when a non-function is passed to Reserve.
@@ -27,17 +34,82 @@ var ErrTooManyArgs = errors.New("too many arguments: too many arguments provided
 
 // Tebata handles signal-triggered function execution.
 type Tebata struct {
-	mutex             sync.Mutex
-	ctx               context.Context
-	cancel            context.CancelFunc
-	signalCh          chan os.Signal
-	reservedFunctions []functionData
+	mutex        sync.Mutex
+	ctx          context.Context
+	cancel       context.CancelFunc
+	signalCh     chan os.Signal
+	signals      []os.Signal
+	handlers     map[os.Signal][]functionData
+	adapters     map[os.Signal][]functionData
+	timeout      time.Duration
+	waitCh       chan waitResult
+	runSeq       int
+	abandonedSeq int
+	errCh        chan HandlerError
+	chainSignals map[os.Signal]bool
+}
+
+// waitResult pairs an exec run's outcome with the sequence number exec
+// assigned it. reportResult compares that number against abandonedSeq and
+// drops the result of a run Wait already gave up waiting on, so it never
+// reaches waitCh. See runSeq/abandonedSeq.
+type waitResult struct {
+	seq int
+	err error
 }
 
 // functionData stores a function and its arguments to be executed when a signal is received.
 type functionData struct {
+	name     string
 	function any
 	args     []any
+	priority int
+
+	// numOut and returnsError describe function's return arity, computed
+	// once at registration so exec can cheaply tell whether a call produced
+	// an error worth publishing.
+	numOut       int
+	returnsError bool
+}
+
+// errCap is the buffer size of the channel returned by Errors. Once full,
+// the oldest unread error is dropped to make room for the newest.
+const errCap = 16
+
+// ErrShutdownTimeout is returned by Wait when the timeout configured via
+// SetTimeout elapses before all functions reserved for the received signal
+// have finished running.
+type ErrShutdownTimeout struct {
+	// Handlers names (or, absent a name, indexes) of the functions that were
+	// still running when the timeout elapsed.
+	Handlers []string
+}
+
+func (e *ErrShutdownTimeout) Error() string {
+	return fmt.Sprintf("tebata: shutdown timed out waiting for handlers: %s", strings.Join(e.Handlers, ", "))
+}
+
+// Shutdowner is implemented by types, such as *http.Server, that support a
+// context-bounded graceful shutdown.
+type Shutdowner interface {
+	Shutdown(ctx context.Context) error
+}
+
+// HandlerError pairs an error returned by a reserved function with the
+// signal that triggered it and the handler's name (or index, if it wasn't
+// reserved with ReserveNamed).
+type HandlerError struct {
+	Signal  os.Signal
+	Handler string
+	Err     error
+}
+
+func (e *HandlerError) Error() string {
+	return fmt.Sprintf("tebata: handler %s for signal %v: %v", e.Handler, e.Signal, e.Err)
+}
+
+func (e *HandlerError) Unwrap() error {
+	return e.Err
 }
 
 // New creates a new Tebata instance and starts listening for the specified signals.
@@ -45,53 +117,327 @@ type functionData struct {
 func New(signals ...os.Signal) *Tebata {
 	ctx, cancel := context.WithCancel(context.Background())
 	s := &Tebata{
-		ctx:      ctx,
-		cancel:   cancel,
-		signalCh: make(chan os.Signal, 1),
+		ctx:          ctx,
+		cancel:       cancel,
+		signalCh:     make(chan os.Signal, 1),
+		signals:      signals,
+		handlers:     make(map[os.Signal][]functionData),
+		adapters:     make(map[os.Signal][]functionData),
+		waitCh:       make(chan waitResult, 1),
+		errCh:        make(chan HandlerError, errCap),
+		chainSignals: make(map[os.Signal]bool),
 	}
+	trackListeners(signals)
 	signal.Notify(s.signalCh, signals...)
 	go s.listen()
 	return s
 }
 
-// listen waits for signals and executes reserved functions when signals are received.
+// listenerCounts tracks, per signal, how many live Tebata instances in this
+// process are listening for it. chainIfNeeded consults it before calling the
+// process-global signal.Reset, so that re-raising one instance's signal
+// doesn't tear down delivery to another instance sharing it.
+var (
+	listenerCountsMu sync.Mutex
+	listenerCounts   = make(map[os.Signal]int)
+)
+
+func trackListeners(signals []os.Signal) {
+	listenerCountsMu.Lock()
+	defer listenerCountsMu.Unlock()
+	for _, sig := range signals {
+		listenerCounts[sig]++
+	}
+}
+
+func untrackListeners(signals []os.Signal) {
+	listenerCountsMu.Lock()
+	defer listenerCountsMu.Unlock()
+	for _, sig := range signals {
+		if listenerCounts[sig] > 0 {
+			listenerCounts[sig]--
+		}
+	}
+}
+
+// SetTimeout bounds how long Wait will block for the reserved functions of a
+// received signal to finish running. Once d elapses, Wait returns an
+// *ErrShutdownTimeout naming whichever handlers are still running instead of
+// blocking forever. It returns s for chaining, e.g.
+// tebata.New(sig1, sig2).SetTimeout(5 * time.Second).
+func (s *Tebata) SetTimeout(d time.Duration) *Tebata {
+	s.mutex.Lock()
+	s.timeout = d
+	s.mutex.Unlock()
+	return s
+}
+
+// SetChainDefault makes tebata re-raise a signal's default disposition, via
+// signal.Reset and a self-sent syscall.Kill, once all of its reserved
+// functions have finished running, for every signal the instance was
+// created with. This lets a SIGINT handler terminate with the conventional
+// 130 exit code, or a SIGTSTP handler actually suspend the process
+// afterward, without the t.Reserve(os.Exit, 0) idiom. Use ReserveThenExit to
+// opt a single signal in instead of every signal the instance listens for.
+// It returns s for chaining.
+//
+// Caveat: signal.Reset operates process-wide, so re-raising a signal while
+// another listener is still registered for it would silently kill that
+// listener's delivery too. tebata tracks its own instances and skips the
+// re-raise (leaving the signal's handlers run but never chaining) whenever
+// another Tebata instance in this process also listens for the signal, but
+// it has no way to see an unrelated signal.Notify call made outside tebata.
+// Avoid chaining a signal that other parts of the application also handle.
+//
+// Combining this with SetTimeout is safe but asynchronous: if the timeout
+// elapses first, Wait returns an *ErrShutdownTimeout immediately, and
+// chaining doesn't happen yet either - it still fires afterward, once the
+// slow handlers actually finish running in the background.
+func (s *Tebata) SetChainDefault() *Tebata {
+	s.mutex.Lock()
+	for _, sig := range s.signals {
+		s.chainSignals[sig] = true
+	}
+	s.mutex.Unlock()
+	return s
+}
+
+// listen waits for signals and executes the functions reserved for the received signal.
 func (s *Tebata) listen() {
 	for {
 		select {
-		case <-s.signalCh:
-			s.exec()
+		case sig := <-s.signalCh:
+			s.exec(sig)
 		case <-s.ctx.Done():
 			return
 		}
 	}
 }
 
-// exec executes all reserved functions.
-func (s *Tebata) exec() {
+// exec runs the functions reserved for sig in descending priority order,
+// running each priority tier's functions concurrently but waiting for a
+// tier to finish before starting the next, then runs any reserved closers
+// and shutdowners one at a time in LIFO order, and reports the outcome on
+// waitCh for Wait to observe. Each run is tagged with a sequence number so a
+// Wait call that already gave up on an earlier run can't be satisfied by
+// that run's belated result; see reportResult.
+func (s *Tebata) exec(sig os.Signal) {
 	s.mutex.Lock()
-	defer s.mutex.Unlock()
+	s.runSeq++
+	seq := s.runSeq
+	handlers := append([]functionData(nil), s.handlers[sig]...)
+	adapters := append([]functionData(nil), s.adapters[sig]...)
+	s.mutex.Unlock()
+
+	sort.SliceStable(handlers, func(i, j int) bool {
+		return handlers[i].priority > handlers[j].priority
+	})
+
+	running := make([]string, len(handlers)+len(adapters))
+	var runningMu sync.Mutex
+	for i, fd := range handlers {
+		running[i] = handlerName(fd, i)
+	}
+	for i, fd := range adapters {
+		running[len(handlers)+i] = adapterName(fd, i)
+	}
 
-	for _, fd := range s.reservedFunctions {
-		// Use reflection to call the function with its arguments
-		function := reflect.ValueOf(fd.function)
-		var args []reflect.Value
-		for _, arg := range fd.args {
-			args = append(args, reflect.ValueOf(arg))
+	allDone := make(chan struct{})
+	go func() {
+		defer close(allDone)
+
+		for tierStart := 0; tierStart < len(handlers); {
+			tierEnd := tierStart + 1
+			for tierEnd < len(handlers) && handlers[tierEnd].priority == handlers[tierStart].priority {
+				tierEnd++
+			}
+
+			var wg sync.WaitGroup
+			wg.Add(tierEnd - tierStart)
+			for i := tierStart; i < tierEnd; i++ {
+				go func(i int, fd functionData) {
+					defer wg.Done()
+					if err := callFunction(fd); err != nil {
+						sendDropOldest(s.errCh, HandlerError{Signal: sig, Handler: handlerName(fd, i), Err: err})
+					}
+
+					runningMu.Lock()
+					running[i] = ""
+					runningMu.Unlock()
+				}(i, handlers[i])
+			}
+			wg.Wait()
+
+			tierStart = tierEnd
+		}
+
+		// Closers and shutdowners run one at a time, in registration order
+		// reversed by reserveAdapter, so LIFO ordering is a property of this
+		// plain sequential loop rather than something riding on priority.
+		for i, fd := range adapters {
+			if err := callFunction(fd); err != nil {
+				sendDropOldest(s.errCh, HandlerError{Signal: sig, Handler: adapterName(fd, i), Err: err})
+			}
+
+			runningMu.Lock()
+			running[len(handlers)+i] = ""
+			runningMu.Unlock()
+		}
+	}()
+
+	if s.timeout <= 0 {
+		<-allDone
+		s.reportResult(seq, nil)
+		s.chainIfNeeded(sig)
+		return
+	}
+
+	select {
+	case <-allDone:
+		s.reportResult(seq, nil)
+		s.chainIfNeeded(sig)
+	case <-time.After(s.timeout):
+		runningMu.Lock()
+		var stillRunning []string
+		for _, name := range running {
+			if name != "" {
+				stillRunning = append(stillRunning, name)
+			}
+		}
+		runningMu.Unlock()
+		s.reportResult(seq, &ErrShutdownTimeout{Handlers: stillRunning})
+
+		// The still-running handlers haven't been abandoned, just not waited
+		// on any longer: once they actually finish, still chain if sig was
+		// configured to, instead of silently never re-raising it. Check the
+		// chain flag up front so a timeout with no chaining configured never
+		// leaves a goroutine blocked on a handler that may never return.
+		s.mutex.Lock()
+		chain := s.chainSignals[sig]
+		s.mutex.Unlock()
+		if chain {
+			go func() {
+				<-allDone
+				s.chainIfNeeded(sig)
+			}()
 		}
-		function.Call(args)
 	}
 }
 
-// Reserve registers a function to be executed when a signal is received.
-// It returns an error if the function or arguments are invalid.
-// It also validates that the provided arguments match the function's parameter types.
-func (s *Tebata) Reserve(function any, args ...any) error {
+// reportResult delivers a run's outcome to whichever Wait call is (or next
+// will be) reading waitCh, unless that run's sequence number was already
+// marked abandoned by a Wait call that gave up on it via ctx cancellation -
+// in which case it's dropped instead of leaking into an unrelated later
+// Wait call as a false completion.
+func (s *Tebata) reportResult(seq int, err error) {
 	s.mutex.Lock()
-	defer s.mutex.Unlock()
+	abandoned := seq <= s.abandonedSeq
+	s.mutex.Unlock()
+	if abandoned {
+		return
+	}
+
+	sendDropOldest(s.waitCh, waitResult{seq: seq, err: err})
+}
+
+// chainIfNeeded re-raises sig's default disposition if it was reserved with
+// SetChainDefault or ReserveThenExit. signal.Reset deregisters sig from
+// signalCh process-wide before the self-kill, so the re-raised signal can't
+// loop back into listen. Because that reset is process-wide, chainIfNeeded
+// first checks listenerCounts and does nothing if another Tebata instance in
+// this process is also listening for sig, so as not to cut off its delivery.
+func (s *Tebata) chainIfNeeded(sig os.Signal) {
+	s.mutex.Lock()
+	chain := s.chainSignals[sig]
+	s.mutex.Unlock()
+
+	if !chain {
+		return
+	}
 
+	sysSig, ok := sig.(syscall.Signal)
+	if !ok {
+		return
+	}
+
+	listenerCountsMu.Lock()
+	sole := listenerCounts[sig] <= 1
+	listenerCountsMu.Unlock()
+	if !sole {
+		return
+	}
+
+	signal.Reset(sig)
+	_ = syscall.Kill(os.Getpid(), sysSig)
+}
+
+// sendDropOldest sends v on ch without blocking, discarding the oldest
+// buffered value to make room if ch is full. This keeps a slow or absent
+// Wait/Errors reader from stalling exec, at the cost of losing stale entries
+// nobody read in time.
+func sendDropOldest[T any](ch chan T, v T) {
+	for {
+		select {
+		case ch <- v:
+			return
+		default:
+			select {
+			case <-ch:
+			default:
+			}
+		}
+	}
+}
+
+// handlerName returns fd's display name, falling back to its index when it
+// wasn't reserved with ReserveNamed.
+func handlerName(fd functionData, i int) string {
+	if fd.name != "" {
+		return fd.name
+	}
+	return "#" + strconv.Itoa(i)
+}
+
+// adapterName returns fd's display name for error/timeout reporting. Closers
+// and shutdowners are always reserved without a name, so it falls back to a
+// distinct "adapter#i" index instead of colliding with handlerName's "#i".
+func adapterName(fd functionData, i int) string {
+	if fd.name != "" {
+		return fd.name
+	}
+	return "adapter#" + strconv.Itoa(i)
+}
+
+// callFunction invokes fd.function with fd.args via reflection, returning
+// the error from its last return value if it has one and the call produced
+// a non-nil error.
+func callFunction(fd functionData) error {
+	function := reflect.ValueOf(fd.function)
+	var args []reflect.Value
+	for _, arg := range fd.args {
+		args = append(args, reflect.ValueOf(arg))
+	}
+	out := function.Call(args)
+
+	if !fd.returnsError {
+		return nil
+	}
+	if err, ok := out[fd.numOut-1].Interface().(error); ok {
+		return err
+	}
+	return nil
+}
+
+// errType is the reflect.Type of the error interface, used to detect
+// functions whose last return value reports an error.
+var errType = reflect.TypeOf((*error)(nil)).Elem()
+
+// newFunctionData validates function and args and builds a functionData entry.
+// It also validates that the provided arguments match the function's parameter types.
+func newFunctionData(name string, function any, args ...any) (functionData, error) {
 	funcValue := reflect.ValueOf(function)
 	if funcValue.Kind() != reflect.Func {
-		return ErrInvalidFunction
+		return functionData{}, ErrInvalidFunction
 	}
 
 	// Get the function type to check parameter types
@@ -101,12 +447,12 @@ func (s *Tebata) Reserve(function any, args ...any) error {
 
 	// Check if we have too few arguments
 	if numArgs < numParams {
-		return ErrTooFewArgs
+		return functionData{}, ErrTooFewArgs
 	}
 
 	// Check if we have too many arguments
 	if numArgs > numParams {
-		return ErrTooManyArgs
+		return functionData{}, ErrTooManyArgs
 	}
 
 	// Check if argument types match parameter types
@@ -122,24 +468,183 @@ func (s *Tebata) Reserve(function any, args ...any) error {
 
 		// Check if the argument can be assigned to the parameter
 		if !argType.AssignableTo(paramType) {
-			return ErrTypeMismatch
+			return functionData{}, ErrTypeMismatch
 		}
 	}
 
-	s.reservedFunctions = append(
-		s.reservedFunctions,
-		functionData{
-			function: function,
-			args:     args,
-		},
-	)
+	numOut := funcType.NumOut()
+	returnsError := numOut > 0 && funcType.Out(numOut-1).Implements(errType)
+
+	return functionData{
+		name:         name,
+		function:     function,
+		args:         args,
+		numOut:       numOut,
+		returnsError: returnsError,
+	}, nil
+}
+
+// Reserve registers a function to be executed when any of the signals the
+// Tebata instance was created with is received. Use ReserveOn to route a
+// function to a single signal instead.
+// It returns an error if the function or arguments are invalid.
+func (s *Tebata) Reserve(function any, args ...any) error {
+	return s.ReserveNamed("", function, args...)
+}
+
+// ReserveNamed behaves like Reserve, but tags the function with name so it
+// can be identified in an *ErrShutdownTimeout returned by Wait.
+func (s *Tebata) ReserveNamed(name string, function any, args ...any) error {
+	fd, err := newFunctionData(name, function, args...)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, sig := range s.signals {
+		s.handlers[sig] = append(s.handlers[sig], fd)
+	}
+
+	return nil
+}
+
+// ReserveWithPriority behaves like Reserve, but runs function before other
+// reserved functions of the same signal with a lower priority, letting
+// callers guarantee an order such as "flush logs before closing the DB
+// before calling os.Exit". Handlers execute in descending priority order;
+// functions with equal priority, including the priority-0 default used by
+// Reserve, run concurrently, so their relative order is not defined.
+// It returns an error if the function or arguments are invalid.
+func (s *Tebata) ReserveWithPriority(priority int, function any, args ...any) error {
+	fd, err := newFunctionData("", function, args...)
+	if err != nil {
+		return err
+	}
+	fd.priority = priority
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, sig := range s.signals {
+		s.handlers[sig] = append(s.handlers[sig], fd)
+	}
+
+	return nil
+}
+
+// ReserveOn registers a function to be executed only when sig is received,
+// letting callers give distinct signals distinct behavior (for example,
+// reloading on SIGHUP but shutting down on SIGINT/SIGTERM).
+// It returns an error if the function or arguments are invalid.
+func (s *Tebata) ReserveOn(sig os.Signal, function any, args ...any) error {
+	fd, err := newFunctionData("", function, args...)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.handlers[sig] = append(s.handlers[sig], fd)
+
+	return nil
+}
+
+// ReserveThenExit registers function for sig like ReserveOn, and marks sig
+// so that once its reserved functions finish running, tebata calls
+// signal.Reset(sig) and re-sends sig to the current process. See
+// SetChainDefault for the rationale and caveats.
+// It returns an error if the function or arguments are invalid.
+func (s *Tebata) ReserveThenExit(sig os.Signal, function any, args ...any) error {
+	if err := s.ReserveOn(sig, function, args...); err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	s.chainSignals[sig] = true
+	s.mutex.Unlock()
+
+	return nil
+}
+
+// ReserveCloser registers c.Close to run when any of the signals the Tebata
+// instance was created with is received, so callers don't need to wrap every
+// resource in a closure themselves. Closers reserved this way run after all
+// regular handlers have finished, one at a time, in LIFO order, matching the
+// defer semantics users expect from cleanup code.
+func (s *Tebata) ReserveCloser(c io.Closer) error {
+	return s.reserveAdapter(c.Close)
+}
+
+// ReserveShutdowner registers sh.Shutdown, run with a background context,
+// when any of the signals the Tebata instance was created with is received.
+// Like ReserveCloser, shutdowners reserved this way run after regular
+// handlers, one at a time, in LIFO order.
+func (s *Tebata) ReserveShutdowner(sh Shutdowner) error {
+	return s.reserveAdapter(func() error { return sh.Shutdown(context.Background()) })
+}
+
+// reserveAdapter registers fn for every signal the instance was created
+// with, in its own list separate from s.handlers so exec can run adapters
+// sequentially regardless of how regular handlers are scheduled. Prepending
+// fd puts later-registered adapters first, giving LIFO order.
+func (s *Tebata) reserveAdapter(fn func() error) error {
+	fd, err := newFunctionData("", fn)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, sig := range s.signals {
+		s.adapters[sig] = append([]functionData{fd}, s.adapters[sig]...)
+	}
 
 	return nil
 }
 
+// Wait blocks until a signal has been received and all of its reserved
+// functions have completed, ctx is done, or the timeout configured via
+// SetTimeout elapses first. It returns nil once the functions have
+// completed, ctx.Err() if ctx is done first, and an *ErrShutdownTimeout if
+// the timeout elapses first, giving callers a supervised
+// graceful-shutdown loop to run instead of a bare select{}.
+//
+// If ctx is done before the in-flight run finishes, that run's result is
+// marked abandoned: it won't be delivered to a later, unrelated Wait call
+// once it does finish, so a supervised shutdown loop's next iteration can't
+// be handed a stale success or failure for a run it never waited on.
+func (s *Tebata) Wait(ctx context.Context) error {
+	select {
+	case res := <-s.waitCh:
+		return res.err
+	case <-ctx.Done():
+		s.mutex.Lock()
+		if s.runSeq > s.abandonedSeq {
+			s.abandonedSeq = s.runSeq
+		}
+		s.mutex.Unlock()
+		return ctx.Err()
+	case <-s.ctx.Done():
+		return nil
+	}
+}
+
+// Errors returns a channel of errors returned by reserved functions whose
+// last return value is a non-nil error. The channel is buffered and lossy:
+// once it fills up, the oldest unread HandlerError is dropped to make room
+// for the newest so a slow or absent reader never blocks exec.
+func (s *Tebata) Errors() <-chan HandlerError {
+	return s.errCh
+}
+
 // Close stops the signal handling and cleans up resources.
 func (s *Tebata) Close() {
 	s.cancel()
 	signal.Stop(s.signalCh)
 	close(s.signalCh)
+	untrackListeners(s.signals)
 }
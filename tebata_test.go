@@ -2,14 +2,31 @@ package tebata
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
+	"os/exec"
 	"strconv"
+	"sync"
 	"syscall"
 	"testing"
+	"time"
 )
 
+// closerFunc adapts a plain func() error to io.Closer, mirroring
+// http.HandlerFunc's func-as-interface pattern.
+type closerFunc func() error
+
+func (f closerFunc) Close() error { return f() }
+
+// shutdownerFunc adapts a plain func(context.Context) error to Shutdowner.
+type shutdownerFunc func(context.Context) error
+
+func (f shutdownerFunc) Shutdown(ctx context.Context) error { return f(ctx) }
+
 func TestNew(t *testing.T) {
 	// Test that New creates a Tebata instance with the correct signals
 	s := New(syscall.SIGINT, syscall.SIGTERM)
@@ -51,9 +68,9 @@ func TestStatus_Reserve(t *testing.T) {
 		t.Errorf("Expected ErrInvalidFunction, got: %v", err)
 	}
 
-	// Test that the function was added to reservedFunctions
-	if len(s.reservedFunctions) != 1 {
-		t.Errorf("Expected 1 reserved function, got %d", len(s.reservedFunctions))
+	// Test that the function was added to the handlers for the signal
+	if len(s.handlers[syscall.SIGINT]) != 1 {
+		t.Errorf("Expected 1 reserved function, got %d", len(s.handlers[syscall.SIGINT]))
 	}
 
 	// Test with correct argument types
@@ -138,7 +155,7 @@ func TestStatus_exec(t *testing.T) {
 }
 
 func TestStatus_exec_race_check(t *testing.T) {
-	done := make(chan int, 1)
+	done := make(chan int, 2)
 
 	stdout := os.Stdout
 	r, w, _ := os.Pipe()
@@ -173,6 +190,7 @@ func TestStatus_exec_race_check(t *testing.T) {
 	s1.signalCh <- os.Interrupt
 	s2.signalCh <- os.Interrupt
 	<-done
+	<-done
 
 	if err := w.Close(); err != nil {
 		t.Errorf("Failed to close pipe writer: %v", err)
@@ -188,6 +206,438 @@ func TestStatus_exec_race_check(t *testing.T) {
 	}
 }
 
+func TestStatus_ReserveOn(t *testing.T) {
+	reloaded := make(chan struct{}, 1)
+	shutdown := make(chan struct{}, 1)
+
+	s := New(syscall.SIGHUP, syscall.SIGTERM)
+	defer s.Close()
+
+	if err := s.ReserveOn(syscall.SIGHUP, func() { reloaded <- struct{}{} }); err != nil {
+		t.Fatalf("Failed to reserve reload function: %v", err)
+	}
+	if err := s.ReserveOn(syscall.SIGTERM, func() { shutdown <- struct{}{} }); err != nil {
+		t.Fatalf("Failed to reserve shutdown function: %v", err)
+	}
+
+	s.signalCh <- syscall.SIGHUP
+	<-reloaded
+
+	select {
+	case <-shutdown:
+		t.Error("shutdown handler ran for SIGHUP")
+	default:
+	}
+
+	s.signalCh <- syscall.SIGTERM
+	<-shutdown
+}
+
+func TestStatus_Wait(t *testing.T) {
+	s := New(syscall.SIGINT)
+	defer s.Close()
+
+	if err := s.Reserve(func() {}); err != nil {
+		t.Fatalf("Failed to reserve function: %v", err)
+	}
+
+	s.signalCh <- syscall.SIGINT
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := s.Wait(ctx); err != nil {
+		t.Errorf("Wait returned unexpected error: %v", err)
+	}
+}
+
+func TestStatus_Wait_timeout(t *testing.T) {
+	s := New(syscall.SIGINT).SetTimeout(10 * time.Millisecond)
+	defer s.Close()
+
+	release := make(chan struct{})
+	if err := s.ReserveNamed("slow", func() { <-release }); err != nil {
+		t.Fatalf("Failed to reserve function: %v", err)
+	}
+	defer close(release)
+
+	s.signalCh <- syscall.SIGINT
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	err := s.Wait(ctx)
+	var timeoutErr *ErrShutdownTimeout
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("Expected *ErrShutdownTimeout, got: %v", err)
+	}
+	if len(timeoutErr.Handlers) != 1 || timeoutErr.Handlers[0] != "slow" {
+		t.Errorf("Expected timeout to name [\"slow\"], got: %v", timeoutErr.Handlers)
+	}
+}
+
+// TestStatus_Wait_ctxTimeoutDoesNotLeakStaleResult guards against a Wait
+// call's ctx expiring while its run is still in flight, only for that run's
+// belated result to be handed to a later, unrelated Wait call as a false
+// success. A supervised shutdown loop must not be told a run finished when
+// it never actually waited on that run's outcome.
+func TestStatus_Wait_ctxTimeoutDoesNotLeakStaleResult(t *testing.T) {
+	s := New(syscall.SIGTERM)
+	defer s.Close()
+
+	release := make(chan struct{})
+	if err := s.Reserve(func() { <-release }); err != nil {
+		t.Fatalf("Failed to reserve function: %v", err)
+	}
+
+	s.signalCh <- syscall.SIGTERM
+
+	ctx1, cancel1 := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel1()
+	if err := s.Wait(ctx1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected context.DeadlineExceeded while the handler still runs, got: %v", err)
+	}
+
+	close(release)
+	// Give exec's goroutine time to finish and report its (now-abandoned)
+	// result before the next Wait call starts.
+	time.Sleep(20 * time.Millisecond)
+
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel2()
+	if err := s.Wait(ctx2); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected a fresh Wait call to block for a new run instead of returning a stale result, got: %v", err)
+	}
+}
+
+func TestStatus_ReserveCloser_ReserveShutdowner_LIFO(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) {
+		mu.Lock()
+		order = append(order, name)
+		mu.Unlock()
+	}
+
+	shutdowner := shutdownerFunc(func(context.Context) error { record("shutdowner"); return nil })
+	closer := closerFunc(func() error { record("closer"); return nil })
+
+	s := New(syscall.SIGTERM)
+	defer s.Close()
+
+	if err := s.ReserveShutdowner(shutdowner); err != nil {
+		t.Fatalf("Failed to reserve shutdowner: %v", err)
+	}
+	if err := s.ReserveCloser(closer); err != nil {
+		t.Fatalf("Failed to reserve closer: %v", err)
+	}
+
+	s.signalCh <- syscall.SIGTERM
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.Wait(ctx); err != nil {
+		t.Fatalf("Wait returned unexpected error: %v", err)
+	}
+
+	if len(order) != 2 || order[0] != "closer" || order[1] != "shutdowner" {
+		t.Errorf("Expected LIFO order [closer shutdowner], got: %v", order)
+	}
+}
+
+func TestStatus_ReserveShutdowner_acceptsHTTPServer(t *testing.T) {
+	s := New(syscall.SIGTERM)
+	defer s.Close()
+
+	srv := &http.Server{}
+	if err := s.ReserveShutdowner(srv); err != nil {
+		t.Fatalf("Failed to reserve *http.Server as a Shutdowner: %v", err)
+	}
+
+	s.signalCh <- syscall.SIGTERM
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.Wait(ctx); err != nil {
+		t.Fatalf("Wait returned unexpected error: %v", err)
+	}
+}
+
+func TestStatus_ReserveWithPriority(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) {
+		mu.Lock()
+		order = append(order, name)
+		mu.Unlock()
+	}
+
+	s := New(syscall.SIGTERM)
+	defer s.Close()
+
+	reservations := []struct {
+		name     string
+		priority int
+	}{
+		{"low", -1},
+		{"default", 0},
+		{"high", 10},
+		{"medium", 5},
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(len(reservations))
+	for _, r := range reservations {
+		r := r
+		go func() {
+			defer wg.Done()
+			fn := func() { record(r.name) }
+			var err error
+			if r.priority == 0 {
+				err = s.Reserve(fn)
+			} else {
+				err = s.ReserveWithPriority(r.priority, fn)
+			}
+			if err != nil {
+				t.Errorf("Failed to reserve %s handler: %v", r.name, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	s.signalCh <- syscall.SIGTERM
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.Wait(ctx); err != nil {
+		t.Fatalf("Wait returned unexpected error: %v", err)
+	}
+
+	want := []string{"high", "medium", "default", "low"}
+	if len(order) != len(want) {
+		t.Fatalf("Expected priority order %v, got %v", want, order)
+	}
+	for i, name := range want {
+		if order[i] != name {
+			t.Errorf("Expected priority order %v, got %v", want, order)
+			break
+		}
+	}
+}
+
+func TestStatus_Errors(t *testing.T) {
+	wantErr := errors.New("boom")
+
+	s := New(syscall.SIGTERM)
+	defer s.Close()
+
+	if err := s.ReserveNamed("failing", func() error { return wantErr }); err != nil {
+		t.Fatalf("Failed to reserve function: %v", err)
+	}
+
+	s.signalCh <- syscall.SIGTERM
+
+	select {
+	case he := <-s.Errors():
+		if he.Handler != "failing" {
+			t.Errorf("Expected handler name %q, got %q", "failing", he.Handler)
+		}
+		if !errors.Is(he.Err, wantErr) {
+			t.Errorf("Expected error %v, got %v", wantErr, he.Err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for handler error")
+	}
+}
+
+// TestStatus_WithChainDefault re-executes the test binary as a subprocess so
+// that the real default disposition of SIGINT (terminate the process with
+// exit code 130) can be observed without killing the test runner itself.
+//
+// Deviation from spec: the request asked for this to exercise
+// syscall.SIGUSR1, but SIGUSR1 can't stand in here: Go's runtime treats
+// signals outside SIGHUP/SIGINT/SIGQUIT/SIGTERM (and the job-control
+// signals) as "caught but no action taken" even after signal.Reset, so
+// self-sending SIGUSR1 after a reset never terminates the process and the
+// chained re-raise would have no observable effect to assert on. SIGINT is
+// used instead so the test can actually verify the re-raise happened. Flagging
+// this explicitly rather than silently swapping the signal named in the request.
+func TestStatus_WithChainDefault(t *testing.T) {
+	if os.Getenv("TEBATA_CHAIN_DEFAULT_HELPER") == "1" {
+		runChainDefaultHelper()
+		return
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=TestStatus_WithChainDefault")
+	cmd.Env = append(os.Environ(), "TEBATA_CHAIN_DEFAULT_HELPER=1")
+	out, err := cmd.CombinedOutput()
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("Expected helper process to exit via signal, got: %v (output: %s)", err, out)
+	}
+
+	status, ok := exitErr.Sys().(syscall.WaitStatus)
+	if !ok {
+		t.Fatalf("Unexpected process state: %v", exitErr.Sys())
+	}
+	if !status.Signaled() || status.Signal() != syscall.SIGINT {
+		t.Fatalf("Expected helper process to be terminated by SIGINT, got status: %v", status)
+	}
+
+	if !bytes.Contains(out, []byte("handled\n")) {
+		t.Errorf("Expected helper output to contain handler output, got: %q", out)
+	}
+}
+
+// runChainDefaultHelper reserves a handler for SIGINT with SetChainDefault,
+// self-delivers SIGINT via syscall.Kill, and expects the default terminate
+// action to end the process once the handler has run.
+func runChainDefaultHelper() {
+	s := New(syscall.SIGINT).SetChainDefault()
+	defer s.Close()
+
+	if err := s.Reserve(func() { fmt.Println("handled") }); err != nil {
+		fmt.Println("reserve failed:", err)
+		os.Exit(1)
+	}
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGINT); err != nil {
+		fmt.Println("kill failed:", err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = s.Wait(ctx)
+
+	// Chaining should have already terminated the process by now; if it
+	// hasn't, fail loudly instead of leaving the parent test to hang.
+	time.Sleep(2 * time.Second)
+	fmt.Println("chain default did not terminate the process")
+	os.Exit(2)
+}
+
+// TestStatus_SetChainDefault_SkipsWhenSharedListener guards against
+// chainIfNeeded calling the process-global signal.Reset while another
+// listener for the same signal is still registered: s2 shares SIGWINCH with
+// s1 and must keep receiving it after s1's chained handler runs.
+func TestStatus_SetChainDefault_SkipsWhenSharedListener(t *testing.T) {
+	sig := syscall.SIGWINCH
+
+	s1 := New(sig).SetChainDefault()
+	defer s1.Close()
+
+	handled := make(chan struct{}, 1)
+	if err := s1.Reserve(func() { handled <- struct{}{} }); err != nil {
+		t.Fatalf("Failed to reserve function: %v", err)
+	}
+
+	s2 := New(sig)
+	defer s2.Close()
+
+	if err := syscall.Kill(os.Getpid(), sig); err != nil {
+		t.Fatalf("Failed to self-signal: %v", err)
+	}
+
+	select {
+	case <-handled:
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for s1's handler to run")
+	}
+
+	// Consume s2's result for the first signal, so the Wait below can only be
+	// satisfied by the second one.
+	ctx0, cancel0 := context.WithTimeout(context.Background(), time.Second)
+	defer cancel0()
+	if err := s2.Wait(ctx0); err != nil {
+		t.Fatalf("Expected s2 to receive the first %v, got: %v", sig, err)
+	}
+
+	// If chainIfNeeded had called the process-global signal.Reset here, s2's
+	// delivery for sig would have been torn down along with it. Confirm s2
+	// still sees a signal fired after s1's chained handler has run.
+	if err := syscall.Kill(os.Getpid(), sig); err != nil {
+		t.Fatalf("Failed to self-signal: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s2.Wait(ctx); err != nil {
+		t.Fatalf("Expected s2 to still receive %v after chaining was skipped, got: %v", sig, err)
+	}
+}
+
+// TestStatus_SetChainDefault_FiresAfterTimeout re-executes the test binary
+// as a subprocess (see TestStatus_WithChainDefault) to prove that chaining
+// still happens once a slow handler finishes, even though SetTimeout's
+// deadline elapsed first and Wait already reported *ErrShutdownTimeout.
+func TestStatus_SetChainDefault_FiresAfterTimeout(t *testing.T) {
+	if os.Getenv("TEBATA_CHAIN_DEFAULT_TIMEOUT_HELPER") == "1" {
+		runChainDefaultTimeoutHelper()
+		return
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=TestStatus_SetChainDefault_FiresAfterTimeout")
+	cmd.Env = append(os.Environ(), "TEBATA_CHAIN_DEFAULT_TIMEOUT_HELPER=1")
+	out, err := cmd.CombinedOutput()
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("Expected helper process to exit via signal, got: %v (output: %s)", err, out)
+	}
+
+	status, ok := exitErr.Sys().(syscall.WaitStatus)
+	if !ok {
+		t.Fatalf("Unexpected process state: %v", exitErr.Sys())
+	}
+	if !status.Signaled() || status.Signal() != syscall.SIGINT {
+		t.Fatalf("Expected helper process to be terminated by SIGINT, got status: %v", status)
+	}
+
+	if !bytes.Contains(out, []byte("timed out\n")) {
+		t.Errorf("Expected helper output to contain the timeout result, got: %q", out)
+	}
+}
+
+// runChainDefaultTimeoutHelper configures a short SetTimeout alongside
+// SetChainDefault and a handler that outlives it: Wait should report
+// *ErrShutdownTimeout immediately, and chaining should still terminate the
+// process afterward, once the handler actually finishes.
+func runChainDefaultTimeoutHelper() {
+	s := New(syscall.SIGINT).SetTimeout(10 * time.Millisecond).SetChainDefault()
+	defer s.Close()
+
+	release := make(chan struct{})
+	if err := s.Reserve(func() { <-release }); err != nil {
+		fmt.Println("reserve failed:", err)
+		os.Exit(1)
+	}
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGINT); err != nil {
+		fmt.Println("kill failed:", err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	var timeoutErr *ErrShutdownTimeout
+	if err := s.Wait(ctx); !errors.As(err, &timeoutErr) {
+		fmt.Println("expected *ErrShutdownTimeout, got:", err)
+		os.Exit(1)
+	}
+	fmt.Println("timed out")
+
+	close(release)
+
+	// Chaining should terminate the process shortly after the handler above
+	// finishes; if it hasn't by now, fail loudly instead of leaving the
+	// parent test to hang.
+	time.Sleep(2 * time.Second)
+	fmt.Println("chain default did not terminate the process after the handler finished")
+	os.Exit(2)
+}
+
 func TestClose(t *testing.T) {
 	s := New(syscall.SIGINT)
 